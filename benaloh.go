@@ -10,11 +10,42 @@ import (
 var one = big.NewInt(1)
 var ErrLargeMessage = errors.New("benaloh: message is larger than the public key size")
 var ErrLargeCipher = errors.New("benaloh: cipher is larger than the public key size")
+var ErrDiscreteLogNotFound = errors.New("benaloh: no discrete log found for the given cipher text")
+
+// bsgsThreshold is the largest R for which an exhaustive search is
+// used to recover the discrete log in Decrypt. Above it, Decrypt
+// switches to the Baby-step giant-step algorithm.
+var bsgsThreshold = big.NewInt(1 << 16)
 
 // PrivateKey represents a Benaloh private key.
 type PrivateKey struct {
 	PublicKey
 	PhiDivR, X *big.Int
+
+	// P, Q are the two primes behind N. They let Precompute build
+	// CRT parameters for faster, blinded decryption.
+	P, Q *big.Int
+
+	// Precomputed holds the CRT parameters built by Precompute.
+	Precomputed *Precomputed
+
+	bsgs *bsgsTable
+}
+
+// Precomputed holds CRT parameters that let decryption perform two
+// exponentiations modulo P and Q instead of one modulo N, the same
+// speed-up crypto/rsa gets from its own Precomputed field.
+type Precomputed struct {
+	Dp, Dq, Qinv *big.Int
+}
+
+// bsgsTable holds the precomputed baby steps used by the Baby-step
+// giant-step discrete log search, so it can be built once and reused
+// across multiple calls to Decrypt against the same private key.
+type bsgsTable struct {
+	m      int64
+	factor *big.Int
+	steps  map[string]int64
 }
 
 // PublicKey represents Benaloh public key.
@@ -90,6 +121,8 @@ func GenerateKey(random io.Reader, bitsize int) (*PrivateKey, error) {
 									},
 									X:       x,
 									PhiDivR: phidivr,
+									P:       p,
+									Q:       q,
 								}, nil
 							}
 						}
@@ -129,9 +162,10 @@ func (pub *PublicKey) Encrypt(plainText []byte) ([]byte, error) {
 // Decrypt decrypts the passed cipher text. It returns
 // an error if cipher text value is larger than modulus N of Public key.
 // Moreover, this works by taking discrete log of a base x to
-// recover original message m. It can only work, if R is small.
-// Otherwise, message can be recovered using Baby-step giant-step
-// algorithm in case of large value of R.
+// recover original message m. For small R the discrete log is
+// recovered by an exhaustive search; for larger R (e.g. a 20-32 bit
+// prime) it switches to the Baby-step giant-step algorithm so
+// decryption stays tractable.
 func (priv *PrivateKey) Decrypt(cipherText []byte) ([]byte, error) {
 	c := new(big.Int).SetBytes(cipherText)
 
@@ -142,16 +176,105 @@ func (priv *PrivateKey) Decrypt(cipherText []byte) ([]byte, error) {
 	// c^phi/r mod n
 	a := new(big.Int).Exp(c, priv.PhiDivR, priv.N)
 
-	// taking discret log of a base x. if R is small,
-	// original message can be recovered by an exhaustive search,
-	// i.e. checking if x^i mod n == a.
-	for i := new(big.Int).Set(one); i.Cmp(priv.R) < 0; i.Add(i, one) {
-		xa := new(big.Int).Exp(priv.X, i, priv.N)
+	if priv.R.Cmp(bsgsThreshold) <= 0 {
+		return exhaustiveDiscreteLog(priv.X, priv.R, priv.N, a)
+	}
+
+	return runBSGS(priv.bsgsTable(), priv.N, a)
+}
+
+// DiscreteLog recovers i in [0, r) such that x^i ≡ a (mod n), using
+// an exhaustive search for small r and the Baby-step giant-step
+// algorithm otherwise. Unlike PrivateKey.Decrypt it needs no secret
+// beyond a itself, so callers that reconstruct a = c^(phi/r) mod n
+// without ever learning phi/r (such as a benaloh/threshold
+// combiner) can still recover the encoded message.
+func DiscreteLog(x, r, n, a *big.Int) ([]byte, error) {
+	if r.Cmp(bsgsThreshold) <= 0 {
+		return exhaustiveDiscreteLog(x, r, n, a)
+	}
+	return runBSGS(buildBSGSTable(x, r, n), n, a)
+}
+
+// exhaustiveDiscreteLog recovers i such that x^i = a (mod n) by
+// checking every candidate in [0, r). It is only fast enough for
+// small r.
+func exhaustiveDiscreteLog(x, r, n, a *big.Int) ([]byte, error) {
+	for i := new(big.Int); i.Cmp(r) < 0; i.Add(i, one) {
+		xa := new(big.Int).Exp(x, i, n)
 		if xa.Cmp(a) == 0 {
 			return i.Bytes(), nil
 		}
 	}
-	return nil, nil
+	return nil, ErrDiscreteLogNotFound
+}
+
+// runBSGS recovers i such that x^i = a (mod n) from a precomputed
+// Baby-step giant-step table.
+func runBSGS(t *bsgsTable, n, a *big.Int) ([]byte, error) {
+	gamma := new(big.Int).Set(a)
+	for i := int64(0); i < t.m; i++ {
+		if j, ok := t.steps[gamma.String()]; ok {
+			return new(big.Int).SetInt64(i*t.m + j).Bytes(), nil
+		}
+		gamma.Mod(gamma.Mul(gamma, t.factor), n)
+	}
+	return nil, ErrDiscreteLogNotFound
+}
+
+// bsgsTable returns the cached Baby-step giant-step table for priv,
+// building it on first use.
+func (priv *PrivateKey) bsgsTable() *bsgsTable {
+	if priv.bsgs != nil {
+		return priv.bsgs
+	}
+	priv.bsgs = buildBSGSTable(priv.X, priv.R, priv.N)
+	return priv.bsgs
+}
+
+// buildBSGSTable builds the baby-step table and giant-step factor
+// used by the Baby-step giant-step discrete log search for the
+// group generated by x of order r, modulo n.
+func buildBSGSTable(x, r, n *big.Int) *bsgsTable {
+	// m = ceil(sqrt(r))
+	m := new(big.Int).Sqrt(r)
+	if new(big.Int).Mul(m, m).Cmp(r) < 0 {
+		m.Add(m, one)
+	}
+	mInt := m.Int64()
+
+	// baby steps: x^j mod n -> j, for j in [0, m)
+	steps := make(map[string]int64, mInt)
+	xj := new(big.Int).Set(one)
+	for j := int64(0); j < mInt; j++ {
+		steps[xj.String()] = j
+		xj.Mod(xj.Mul(xj, x), n)
+	}
+
+	// f = x^(-m) mod n, via modular inverse of x^m mod n
+	xm := new(big.Int).Exp(x, m, n)
+	factor := new(big.Int).ModInverse(xm, n)
+
+	return &bsgsTable{m: mInt, factor: factor, steps: steps}
+}
+
+// Decryptor wraps a PrivateKey whose Baby-step giant-step table has
+// been precomputed, so that repeated decryptions of large-R cipher
+// texts do not pay the table-building cost more than once.
+type Decryptor struct {
+	priv *PrivateKey
+}
+
+// NewDecryptor builds the Baby-step giant-step table for priv and
+// returns a Decryptor that reuses it across calls to Decrypt.
+func NewDecryptor(priv *PrivateKey) *Decryptor {
+	priv.bsgsTable()
+	return &Decryptor{priv: priv}
+}
+
+// Decrypt decrypts cipherText using the wrapped private key.
+func (d *Decryptor) Decrypt(cipherText []byte) ([]byte, error) {
+	return d.priv.Decrypt(cipherText)
 }
 
 // HomomorphicEncTwo performs homomorphic operation over two chiphers.
@@ -0,0 +1,75 @@
+package benaloh
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// TestEncryptDecryptRoundTrip exercises the exhaustive discrete-log
+// path end to end, including the m = 0 edge case: Encrypt accepts it,
+// so Decrypt must be able to recover it too.
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	for _, m := range [][]byte{{0}, {1}, {7}, {42}} {
+		ct, err := priv.PublicKey.Encrypt(m)
+		if err != nil {
+			t.Fatalf("Encrypt(%v): %v", m, err)
+		}
+
+		got, err := priv.Decrypt(ct)
+		if err != nil {
+			t.Fatalf("Decrypt(Encrypt(%v)): %v", m, err)
+		}
+
+		gotVal := new(big.Int).SetBytes(got)
+		wantVal := new(big.Int).SetBytes(m)
+		if gotVal.Cmp(wantVal) != 0 {
+			t.Errorf("Decrypt(Encrypt(%v)) = %v, want %v", m, gotVal, wantVal)
+		}
+	}
+}
+
+// TestEncryptDecryptRoundTripBSGS exercises the Baby-step giant-step
+// path, including through a Decryptor with a precomputed table.
+// GenerateKey at a bitsize large enough to push R past bsgsThreshold
+// would be too slow for a test (see GenerateKey's prime search), so
+// bsgsThreshold is temporarily lowered below the ~12-bit R produced
+// at bitsize 24 instead.
+func TestEncryptDecryptRoundTripBSGS(t *testing.T) {
+	old := bsgsThreshold
+	bsgsThreshold = big.NewInt(1 << 8)
+	defer func() { bsgsThreshold = old }()
+
+	priv, err := GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if priv.R.Cmp(bsgsThreshold) <= 0 {
+		t.Fatalf("R = %v did not exceed lowered bsgsThreshold = %v", priv.R, bsgsThreshold)
+	}
+
+	d := NewDecryptor(priv)
+
+	for _, m := range [][]byte{{0}, {1}, {7}, {42}} {
+		ct, err := priv.PublicKey.Encrypt(m)
+		if err != nil {
+			t.Fatalf("Encrypt(%v): %v", m, err)
+		}
+
+		got, err := d.Decrypt(ct)
+		if err != nil {
+			t.Fatalf("Decrypt(Encrypt(%v)): %v", m, err)
+		}
+
+		gotVal := new(big.Int).SetBytes(got)
+		wantVal := new(big.Int).SetBytes(m)
+		if gotVal.Cmp(wantVal) != 0 {
+			t.Errorf("Decrypt(Encrypt(%v)) = %v, want %v", m, gotVal, wantVal)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package benaloh
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// Precompute builds and caches the CRT parameters used by
+// BlindedDecrypt. It is safe, but not required, to call ahead of
+// time; BlindedDecrypt computes them lazily on first use. It is a
+// no-op if priv was decoded without P and Q (see ParsePKCS8PrivateKey),
+// since CRT decryption is impossible without the two factors of N.
+func (priv *PrivateKey) Precompute() {
+	if priv.Precomputed != nil || priv.P == nil || priv.Q == nil {
+		return
+	}
+
+	pMinus1 := new(big.Int).Sub(priv.P, one)
+	qMinus1 := new(big.Int).Sub(priv.Q, one)
+	priv.Precomputed = &Precomputed{
+		Dp:   new(big.Int).Mod(priv.PhiDivR, pMinus1),
+		Dq:   new(big.Int).Mod(priv.PhiDivR, qMinus1),
+		Qinv: new(big.Int).ModInverse(priv.Q, priv.P),
+	}
+}
+
+// crtExp computes cipher^PhiDivR mod N, using the CRT parameters
+// from Precompute when available and falling back to a direct
+// exponentiation mod N otherwise.
+func (priv *PrivateKey) crtExp(cipher *big.Int) *big.Int {
+	priv.Precompute()
+	if priv.Precomputed == nil {
+		return new(big.Int).Exp(cipher, priv.PhiDivR, priv.N)
+	}
+
+	m1 := new(big.Int).Exp(cipher, priv.Precomputed.Dp, priv.P)
+	m2 := new(big.Int).Exp(cipher, priv.Precomputed.Dq, priv.Q)
+
+	// CRT recombination: m2 + Q * ((m1-m2)*Qinv mod P)
+	h := new(big.Int).Mod(
+		new(big.Int).Mul(new(big.Int).Sub(m1, m2), priv.Precomputed.Qinv),
+		priv.P,
+	)
+	return new(big.Int).Add(m2, new(big.Int).Mul(h, priv.Q))
+}
+
+// BlindedDecrypt decrypts cipherText the same way Decrypt does, but
+// randomizes the cipher text before the modular exponentiation so
+// its timing does not depend on the real cipher text, only on a
+// fresh random blinding factor, the same protection crypto/rsa
+// offers through its own blinding. Unlike RSA blinding, no
+// unblinding multiplication is needed afterwards: raising c*r^R to
+// PhiDivR yields c^PhiDivR * r^phi mod n, and r^phi ≡ 1 mod n by
+// Euler's theorem, so the blind cancels on its own.
+func (priv *PrivateKey) BlindedDecrypt(cipherText []byte) ([]byte, error) {
+	c := new(big.Int).SetBytes(cipherText)
+	if c.Cmp(priv.N) == 1 { // c < n
+		return nil, ErrLargeCipher
+	}
+
+	r, err := rand.Int(rand.Reader, new(big.Int).Sub(priv.N, one))
+	if err != nil {
+		return nil, err
+	}
+	r.Add(r, one) // r in [1, N-1], so it is never zero
+
+	blinded := new(big.Int).Mod(
+		new(big.Int).Mul(c, new(big.Int).Exp(r, priv.R, priv.N)),
+		priv.N,
+	)
+
+	a := priv.crtExp(blinded)
+
+	if priv.R.Cmp(bsgsThreshold) <= 0 {
+		return exhaustiveDiscreteLog(priv.X, priv.R, priv.N, a)
+	}
+	return runBSGS(priv.bsgsTable(), priv.N, a)
+}
+
+// EncryptConstantTime behaves like Encrypt, but raises pub.Y to m
+// using fixed-window exponentiation: the same number of squarings
+// and multiplications run regardless of m's bit pattern, so the
+// shape of the exponentiation does not leak m through timing.
+func (pub *PublicKey) EncryptConstantTime(plainText []byte) ([]byte, error) {
+	u, err := rand.Int(rand.Reader, new(big.Int).Sub(pub.N, one))
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(big.Int).SetBytes(plainText)
+	if m.Cmp(pub.R) == 1 { // m < R
+		return nil, ErrLargeMessage
+	}
+
+	ym := fixedWindowExp(pub.Y, m, pub.R.BitLen(), pub.N)
+
+	// c = y^m * u^r mod n
+	c := new(big.Int).Mod(
+		new(big.Int).Mul(ym, new(big.Int).Exp(u, pub.R, pub.N)),
+		pub.N,
+	)
+	return c.Bytes(), nil
+}
+
+// fixedWindowExp computes base^exp mod n using exactly bits
+// square-and-always-multiply steps, so the number of multiplications
+// performed does not depend on exp's value.
+func fixedWindowExp(base, exp *big.Int, bits int, n *big.Int) *big.Int {
+	result := new(big.Int).Set(one)
+	b := new(big.Int).Mod(base, n)
+
+	for i := bits - 1; i >= 0; i-- {
+		result.Mod(result.Mul(result, result), n)
+
+		factor := new(big.Int).Set(b)
+		if exp.Bit(i) == 0 {
+			factor.Set(one)
+		}
+		result.Mod(result.Mul(result, factor), n)
+	}
+	return result
+}
@@ -0,0 +1,40 @@
+package benaloh
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+// TestBlindedDecryptRoundTrip exercises both the constant-time
+// EncryptConstantTime path and the blinded, CRT-accelerated
+// BlindedDecrypt path, with and without Precompute having been
+// called first.
+func TestBlindedDecryptRoundTrip(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	plainText := []byte{9}
+	ct, err := priv.PublicKey.EncryptConstantTime(plainText)
+	if err != nil {
+		t.Fatalf("EncryptConstantTime: %v", err)
+	}
+
+	got, err := priv.BlindedDecrypt(ct)
+	if err != nil {
+		t.Fatalf("BlindedDecrypt (lazy CRT): %v", err)
+	}
+	if got[0] != plainText[0] {
+		t.Errorf("BlindedDecrypt = %v, want %v", got, plainText)
+	}
+
+	priv.Precompute()
+	got, err = priv.BlindedDecrypt(ct)
+	if err != nil {
+		t.Fatalf("BlindedDecrypt (precomputed CRT): %v", err)
+	}
+	if got[0] != plainText[0] {
+		t.Errorf("BlindedDecrypt after Precompute = %v, want %v", got, plainText)
+	}
+}
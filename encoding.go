@@ -0,0 +1,304 @@
+package benaloh
+
+import (
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrMalformedPublicKey  = errors.New("benaloh: malformed public key")
+	ErrMalformedPrivateKey = errors.New("benaloh: malformed private key")
+	ErrMalformedPEM        = errors.New("benaloh: malformed PEM block")
+)
+
+const (
+	pemPublicKeyType  = "BENALOH PUBLIC KEY"
+	pemPrivateKeyType = "BENALOH PRIVATE KEY"
+)
+
+// pkixPublicKey is the ASN.1/DER representation of a PublicKey,
+// named after x509's pkixPublicKey to signal the same role: a
+// self-describing, algorithm-specific encoding of the public
+// parameters.
+type pkixPublicKey struct {
+	N, R, Y *big.Int
+}
+
+// pkcs8PrivateKey is the ASN.1/DER representation of a PrivateKey,
+// named after x509's PKCS8 private key encoding. P and Q are carried
+// as optional fields so that Precompute's CRT fast path survives a
+// round trip through MarshalPKCS8PrivateKey/ParsePKCS8PrivateKey;
+// they are absent only when decoding a DER blob produced before this
+// field was added, in which case Precompute is a permanent no-op for
+// the resulting key (see blinding.go).
+type pkcs8PrivateKey struct {
+	N, R, Y, PhiDivR, X *big.Int
+	P, Q                *big.Int `asn1:"optional"`
+}
+
+// checkPub sanity-checks a decoded PublicKey so that a malformed or
+// truncated encoding cannot silently pass as valid, mirroring
+// crypto/rsa's checkPub.
+func checkPub(pub *PublicKey) error {
+	if pub.N == nil || pub.R == nil || pub.Y == nil {
+		return ErrMalformedPublicKey
+	}
+	if pub.N.Sign() <= 0 || pub.R.Cmp(one) <= 0 {
+		return ErrMalformedPublicKey
+	}
+	if pub.Y.Sign() <= 0 || pub.Y.Cmp(pub.N) >= 0 {
+		return ErrMalformedPublicKey
+	}
+	return nil
+}
+
+// checkPriv sanity-checks a decoded PrivateKey, additionally
+// confirming that X is the one GenerateKey would have derived from
+// Y, PhiDivR and N, that X has order dividing R as the scheme
+// requires, and that P and Q (when present) really are the two
+// factors of N, since Precompute trusts them blindly when building
+// CRT parameters.
+func checkPriv(priv *PrivateKey) error {
+	if err := checkPub(&priv.PublicKey); err != nil {
+		return err
+	}
+	if priv.PhiDivR == nil || priv.X == nil || priv.PhiDivR.Sign() <= 0 {
+		return ErrMalformedPrivateKey
+	}
+	if priv.X.Cmp(one) <= 0 || priv.X.Cmp(priv.N) >= 0 {
+		return ErrMalformedPrivateKey
+	}
+	if (priv.P == nil) != (priv.Q == nil) {
+		return ErrMalformedPrivateKey
+	}
+	if priv.P != nil {
+		if priv.P.Cmp(one) <= 0 || priv.Q.Cmp(one) <= 0 {
+			return ErrMalformedPrivateKey
+		}
+		if new(big.Int).Mul(priv.P, priv.Q).Cmp(priv.N) != 0 {
+			return ErrMalformedPrivateKey
+		}
+		if new(big.Int).GCD(nil, nil, priv.P, priv.Q).Cmp(one) != 0 {
+			return ErrMalformedPrivateKey
+		}
+	}
+
+	// x == y^(phi/r) mod n
+	x := new(big.Int).Exp(priv.Y, priv.PhiDivR, priv.N)
+	if x.Cmp(priv.X) != 0 {
+		return ErrMalformedPrivateKey
+	}
+
+	// x^r == 1 mod n, i.e. r divides the order of x
+	if new(big.Int).Exp(priv.X, priv.R, priv.N).Cmp(one) != 0 {
+		return ErrMalformedPrivateKey
+	}
+
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding pub as
+// ASN.1 DER.
+func (pub *PublicKey) MarshalBinary() ([]byte, error) {
+	return MarshalPKIXPublicKey(pub)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding an
+// ASN.1 DER public key produced by MarshalBinary into pub.
+func (pub *PublicKey) UnmarshalBinary(data []byte) error {
+	decoded, err := ParsePKIXPublicKey(data)
+	if err != nil {
+		return err
+	}
+	*pub = *decoded
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding priv
+// as ASN.1 DER.
+func (priv *PrivateKey) MarshalBinary() ([]byte, error) {
+	return MarshalPKCS8PrivateKey(priv)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding an
+// ASN.1 DER private key produced by MarshalBinary into priv.
+func (priv *PrivateKey) UnmarshalBinary(data []byte) error {
+	decoded, err := ParsePKCS8PrivateKey(data)
+	if err != nil {
+		return err
+	}
+	*priv = *decoded
+	return nil
+}
+
+// MarshalPKIXPublicKey converts pub to ASN.1 DER form.
+func MarshalPKIXPublicKey(pub *PublicKey) ([]byte, error) {
+	if err := checkPub(pub); err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkixPublicKey{N: pub.N, R: pub.R, Y: pub.Y})
+}
+
+// ParsePKIXPublicKey parses an ASN.1 DER public key produced by
+// MarshalPKIXPublicKey.
+func ParsePKIXPublicKey(der []byte) (*PublicKey, error) {
+	var decoded pkixPublicKey
+	rest, err := asn1.Unmarshal(der, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrMalformedPublicKey
+	}
+
+	pub := &PublicKey{N: decoded.N, R: decoded.R, Y: decoded.Y}
+	if err := checkPub(pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// MarshalPKCS8PrivateKey converts priv to ASN.1 DER form, including
+// P and Q when present so the decoded key keeps Precompute's CRT
+// fast path.
+func MarshalPKCS8PrivateKey(priv *PrivateKey) ([]byte, error) {
+	if err := checkPriv(priv); err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8PrivateKey{
+		N:       priv.N,
+		R:       priv.R,
+		Y:       priv.Y,
+		PhiDivR: priv.PhiDivR,
+		X:       priv.X,
+		P:       priv.P,
+		Q:       priv.Q,
+	})
+}
+
+// ParsePKCS8PrivateKey parses an ASN.1 DER private key produced by
+// MarshalPKCS8PrivateKey.
+func ParsePKCS8PrivateKey(der []byte) (*PrivateKey, error) {
+	var decoded pkcs8PrivateKey
+	rest, err := asn1.Unmarshal(der, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrMalformedPrivateKey
+	}
+
+	priv := &PrivateKey{
+		PublicKey: PublicKey{N: decoded.N, R: decoded.R, Y: decoded.Y},
+		PhiDivR:   decoded.PhiDivR,
+		X:         decoded.X,
+		P:         decoded.P,
+		Q:         decoded.Q,
+	}
+	if err := checkPriv(priv); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// EncodePrivateKeyPEM encodes priv as a PEM block.
+func EncodePrivateKeyPEM(priv *PrivateKey) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPrivateKeyType, Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM decodes a PEM block produced by
+// EncodePrivateKeyPEM.
+func ParsePrivateKeyPEM(data []byte) (*PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemPrivateKeyType {
+		return nil, ErrMalformedPEM
+	}
+	return ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// EncodePublicKeyPEM encodes pub as a PEM block.
+func EncodePublicKeyPEM(pub *PublicKey) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemPublicKeyType, Bytes: der}), nil
+}
+
+// ParsePublicKeyPEM decodes a PEM block produced by
+// EncodePublicKeyPEM.
+func ParsePublicKeyPEM(data []byte) (*PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemPublicKeyType {
+		return nil, ErrMalformedPEM
+	}
+	return ParsePKIXPublicKey(block.Bytes)
+}
+
+// publicKeyJSON mirrors the json:"n" style used by the Paillier
+// implementations this package follows.
+type publicKeyJSON struct {
+	N *big.Int `json:"n"`
+	R *big.Int `json:"r"`
+	Y *big.Int `json:"y"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (pub *PublicKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(publicKeyJSON{N: pub.N, R: pub.R, Y: pub.Y})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (pub *PublicKey) UnmarshalJSON(data []byte) error {
+	var decoded publicKeyJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	pub.N, pub.R, pub.Y = decoded.N, decoded.R, decoded.Y
+	return checkPub(pub)
+}
+
+// privateKeyJSON mirrors the json:"n" style used by the Paillier
+// implementations this package follows. P and Q are omitempty so
+// that Precompute's CRT fast path survives a round trip through
+// MarshalJSON/UnmarshalJSON; they are absent only for a PrivateKey
+// that never had them (see blinding.go).
+type privateKeyJSON struct {
+	PublicKey publicKeyJSON `json:"public_key"`
+	PhiDivR   *big.Int      `json:"phi_div_r"`
+	X         *big.Int      `json:"x"`
+	P         *big.Int      `json:"p,omitempty"`
+	Q         *big.Int      `json:"q,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (priv *PrivateKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(privateKeyJSON{
+		PublicKey: publicKeyJSON{N: priv.N, R: priv.R, Y: priv.Y},
+		PhiDivR:   priv.PhiDivR,
+		X:         priv.X,
+		P:         priv.P,
+		Q:         priv.Q,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (priv *PrivateKey) UnmarshalJSON(data []byte) error {
+	var decoded privateKeyJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	priv.PublicKey = PublicKey{N: decoded.PublicKey.N, R: decoded.PublicKey.R, Y: decoded.PublicKey.Y}
+	priv.PhiDivR = decoded.PhiDivR
+	priv.X = decoded.X
+	priv.P = decoded.P
+	priv.Q = decoded.Q
+	return checkPriv(priv)
+}
@@ -0,0 +1,223 @@
+package benaloh
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// TestPrivateKeyEncodingPreservesCRTFastPath checks that P and Q
+// survive a round trip through both the DER and JSON private key
+// encodings, so Precompute still builds CRT parameters afterwards.
+func TestPrivateKeyEncodingPreservesCRTFastPath(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	der, err := MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	fromDER, err := ParsePKCS8PrivateKey(der)
+	if err != nil {
+		t.Fatalf("ParsePKCS8PrivateKey: %v", err)
+	}
+	fromDER.Precompute()
+	if fromDER.Precomputed == nil {
+		t.Error("Precompute is a no-op after a DER round trip; P/Q were lost")
+	}
+
+	data, err := json.Marshal(priv)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var fromJSON PrivateKey
+	if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	fromJSON.Precompute()
+	if fromJSON.Precomputed == nil {
+		t.Error("Precompute is a no-op after a JSON round trip; P/Q were lost")
+	}
+}
+
+// TestParsePublicKeyRejectsMalformed checks that UnmarshalJSON rejects
+// public keys whose parameters fail checkPub's sanity checks, instead
+// of silently accepting them.
+func TestParsePublicKeyRejectsMalformed(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(m map[string]interface{})
+	}{
+		{"zero N", func(m map[string]interface{}) { m["n"] = json.Number("0") }},
+		{"negative N", func(m map[string]interface{}) { m["n"] = json.Number("-1") }},
+		{"Y equal to N", func(m map[string]interface{}) { m["y"] = m["n"] }},
+		{"Y zero", func(m map[string]interface{}) { m["y"] = json.Number("0") }},
+		{"R equal to one", func(m map[string]interface{}) { m["r"] = json.Number("1") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(&priv.PublicKey)
+			if err != nil {
+				t.Fatalf("json.Marshal: %v", err)
+			}
+			var m map[string]interface{}
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatalf("json.Unmarshal into map: %v", err)
+			}
+			tt.mutate(m)
+			tampered, err := json.Marshal(m)
+			if err != nil {
+				t.Fatalf("json.Marshal tampered: %v", err)
+			}
+
+			var pub PublicKey
+			if err := json.Unmarshal(tampered, &pub); err != ErrMalformedPublicKey {
+				t.Errorf("UnmarshalJSON with %s: got err %v, want %v", tt.name, err, ErrMalformedPublicKey)
+			}
+		})
+	}
+}
+
+// TestParsePrivateKeyRejectsMalformedPQ checks that UnmarshalJSON
+// rejects a private key whose P and Q no longer behave as the two
+// factors of N, since Precompute trusts them blindly when building
+// CRT parameters.
+func TestParsePrivateKeyRejectsMalformedPQ(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(m map[string]interface{})
+	}{
+		{"P equal to one", func(m map[string]interface{}) { m["p"] = json.Number("1") }},
+		{"P times Q does not equal N", func(m map[string]interface{}) {
+			m["p"] = json.Number(new(big.Int).Add(priv.P, one).String())
+		}},
+		{"P and Q not coprime", func(m map[string]interface{}) {
+			// N must still equal P*Q for the coprimality check to be
+			// reached at all (checkPriv checks P*Q == N first), so a
+			// real key's distinct-prime P, Q can't be replaced in
+			// place: any other factor pair of N is either (Q, P) or
+			// involves 1, both already covered by other cases. Swap
+			// in a self-contained fake N = 6*10 with gcd(6, 10) = 2
+			// instead; PhiDivR and X only need to satisfy checkPriv's
+			// earlier nil/range checks; the exponentiation identity
+			// is never reached because the coprimality check returns
+			// first.
+			pubKey := m["public_key"].(map[string]interface{})
+			pubKey["n"] = json.Number("60")
+			pubKey["y"] = json.Number("7")
+			m["phi_div_r"] = json.Number("1")
+			m["x"] = json.Number("2")
+			m["p"] = json.Number("6")
+			m["q"] = json.Number("10")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(priv)
+			if err != nil {
+				t.Fatalf("json.Marshal: %v", err)
+			}
+			var m map[string]interface{}
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatalf("json.Unmarshal into map: %v", err)
+			}
+			tt.mutate(m)
+			tampered, err := json.Marshal(m)
+			if err != nil {
+				t.Fatalf("json.Marshal tampered: %v", err)
+			}
+
+			var decoded PrivateKey
+			if err := json.Unmarshal(tampered, &decoded); err != ErrMalformedPrivateKey {
+				t.Errorf("UnmarshalJSON with %s: got err %v, want %v", tt.name, err, ErrMalformedPrivateKey)
+			}
+		})
+	}
+}
+
+// TestPublicKeyEncodingRoundTrip checks that a PublicKey survives
+// round trips through ASN.1 DER, PEM and JSON unchanged.
+func TestPublicKeyEncodingRoundTrip(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	pub := &priv.PublicKey
+
+	der, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	fromDER, err := ParsePKIXPublicKey(der)
+	if err != nil {
+		t.Fatalf("ParsePKIXPublicKey: %v", err)
+	}
+	if fromDER.N.Cmp(pub.N) != 0 || fromDER.R.Cmp(pub.R) != 0 || fromDER.Y.Cmp(pub.Y) != 0 {
+		t.Error("ParsePKIXPublicKey(MarshalPKIXPublicKey(pub)) changed N, R or Y")
+	}
+
+	pemData, err := EncodePublicKeyPEM(pub)
+	if err != nil {
+		t.Fatalf("EncodePublicKeyPEM: %v", err)
+	}
+	fromPEM, err := ParsePublicKeyPEM(pemData)
+	if err != nil {
+		t.Fatalf("ParsePublicKeyPEM: %v", err)
+	}
+	if fromPEM.N.Cmp(pub.N) != 0 || fromPEM.R.Cmp(pub.R) != 0 || fromPEM.Y.Cmp(pub.Y) != 0 {
+		t.Error("ParsePublicKeyPEM(EncodePublicKeyPEM(pub)) changed N, R or Y")
+	}
+
+	data, err := json.Marshal(pub)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var fromJSON PublicKey
+	if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if fromJSON.N.Cmp(pub.N) != 0 || fromJSON.R.Cmp(pub.R) != 0 || fromJSON.Y.Cmp(pub.Y) != 0 {
+		t.Error("json round trip changed N, R or Y")
+	}
+}
+
+// TestPrivateKeyPEMRoundTrip checks that a PrivateKey survives a
+// round trip through EncodePrivateKeyPEM/ParsePrivateKeyPEM,
+// including its CRT fast path.
+func TestPrivateKeyPEMRoundTrip(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	pemData, err := EncodePrivateKeyPEM(priv)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyPEM: %v", err)
+	}
+	fromPEM, err := ParsePrivateKeyPEM(pemData)
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyPEM: %v", err)
+	}
+	if fromPEM.PhiDivR.Cmp(priv.PhiDivR) != 0 || fromPEM.X.Cmp(priv.X) != 0 {
+		t.Error("ParsePrivateKeyPEM(EncodePrivateKeyPEM(priv)) changed PhiDivR or X")
+	}
+	fromPEM.Precompute()
+	if fromPEM.Precomputed == nil {
+		t.Error("Precompute is a no-op after a PEM round trip; P/Q were lost")
+	}
+}
@@ -0,0 +1,89 @@
+package benaloh
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// ReRandomize multiplies cipherText by a fresh random u^R mod n,
+// producing a new, unlinkable encryption of the same plaintext. This
+// is the building block mix-nets use to shuffle ballots without
+// being able to tell which output cipher text came from which input.
+func (pub *PublicKey) ReRandomize(cipherText []byte) ([]byte, error) {
+	c := new(big.Int).SetBytes(cipherText)
+	if c.Cmp(pub.N) == 1 { // c < n
+		return nil, ErrLargeCipher
+	}
+
+	u, err := rand.Int(rand.Reader, new(big.Int).Sub(pub.N, one))
+	if err != nil {
+		return nil, err
+	}
+
+	out := new(big.Int).Mod(
+		new(big.Int).Mul(c, new(big.Int).Exp(u, pub.R, pub.N)),
+		pub.N,
+	)
+	return out.Bytes(), nil
+}
+
+// AddConstant homomorphically adds the public plaintext k to the
+// message encrypted in cipherText, returning an encryption of m+k.
+func (pub *PublicKey) AddConstant(cipherText, k []byte) ([]byte, error) {
+	c := new(big.Int).SetBytes(cipherText)
+	if c.Cmp(pub.N) == 1 { // c < n
+		return nil, ErrLargeCipher
+	}
+
+	// c * y^k mod n
+	out := new(big.Int).Mod(
+		new(big.Int).Mul(c, new(big.Int).Exp(pub.Y, new(big.Int).SetBytes(k), pub.N)),
+		pub.N,
+	)
+	return out.Bytes(), nil
+}
+
+// MulConstant homomorphically scales the message encrypted in
+// cipherText by the public integer k, returning an encryption of
+// m*k.
+func (pub *PublicKey) MulConstant(cipherText, k []byte) ([]byte, error) {
+	c := new(big.Int).SetBytes(cipherText)
+	if c.Cmp(pub.N) == 1 { // c < n
+		return nil, ErrLargeCipher
+	}
+
+	// c^k mod n
+	out := new(big.Int).Exp(c, new(big.Int).SetBytes(k), pub.N)
+	return out.Bytes(), nil
+}
+
+// Aggregator accumulates the homomorphic sum of a stream of cipher
+// texts, keeping the running product in a single *big.Int instead of
+// re-parsing every accumulated cipher text from bytes on each Add,
+// unlike HommorphicEncMultiple.
+type Aggregator struct {
+	pub   *PublicKey
+	total *big.Int
+}
+
+// NewAggregator returns an Aggregator for pub with an empty running
+// sum, i.e. an encryption of zero.
+func NewAggregator(pub *PublicKey) *Aggregator {
+	return &Aggregator{pub: pub, total: new(big.Int).Set(one)}
+}
+
+// Add folds cipherText into the running sum.
+func (agg *Aggregator) Add(cipherText []byte) error {
+	c := new(big.Int).SetBytes(cipherText)
+	if c.Cmp(agg.pub.N) == 1 { // c < n
+		return ErrLargeCipher
+	}
+	agg.total.Mod(agg.total.Mul(agg.total, c), agg.pub.N)
+	return nil
+}
+
+// Sum returns the cipher text encrypting the sum of every message
+// added so far.
+func (agg *Aggregator) Sum() []byte {
+	return agg.total.Bytes()
+}
@@ -0,0 +1,364 @@
+// Package threshold implements (t, n)-threshold Benaloh decryption:
+// the secret exponent used to decrypt a cipher text is Shamir-shared
+// across n trustees, and any t of them can cooperate to recover the
+// plain text without ever reconstructing the full private key. This
+// is the classic building block behind Benaloh's original use case,
+// verifiable e-voting, where no single trustee should be able to
+// open a ballot alone.
+package threshold
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/mirzazhar/benaloh"
+)
+
+var one = big.NewInt(1)
+
+var (
+	ErrThreshold        = errors.New("threshold: threshold must satisfy 1 <= t <= n")
+	ErrNotEnoughShares  = errors.New("threshold: fewer partial decryptions supplied than the threshold")
+	ErrInvalidShare     = errors.New("threshold: a partial decryption failed its equality proof")
+	ErrTrusteesTooLarge = errors.New("threshold: R must be larger than the number of trustees")
+)
+
+// PublicKey is a Benaloh public key set up for (t, n)-threshold
+// decryption. X is carried alongside the usual parameters because
+// recovering a message still requires the secret exponent PhiDivR,
+// which is Shamir-shared across the trustees rather than held by
+// any single party; knowing X alone does not help an attacker
+// decrypt without combining at least Threshold shares. Commitments
+// holds the canonical Y^Value mod N published for each trustee at
+// generation time (Commitments[i] for 1-based trustee index i+1), so
+// CombineShares can check a submitted PartialDecryption against the
+// commitment it is actually supposed to match, rather than trusting
+// whatever Commitment rides along with it.
+type PublicKey struct {
+	benaloh.PublicKey
+	X                   *big.Int
+	Threshold, Trustees int
+	Commitments         []*big.Int
+}
+
+// KeyShare is a single trustee's share of the secret exponent
+// PhiDivR, obtained by Shamir-secret-sharing it over the integers
+// (not modulo N or R): PhiDivR must be reconstructed exactly for
+// decryption to succeed, and reducing shares modulo a ring whose
+// order is unrelated to PhiDivR's own size would reconstruct the
+// wrong value.
+type KeyShare struct {
+	Index      int      // 1-based trustee index; the Shamir x-coordinate
+	Value      *big.Int // f(Index), an unreduced integer
+	Commitment *big.Int // Y^Value mod N, published so a combiner can spot a bad partial
+}
+
+// PartialDecryption is one trustee's contribution towards decrypting
+// a cipher text, produced by PartialDecrypt.
+type PartialDecryption struct {
+	Index      int
+	Value      *big.Int // cipherText^Value mod N
+	Commitment *big.Int // the trustee's published KeyShare.Commitment
+	Proof      *EqualityProof
+}
+
+// EqualityProof is a Fiat-Shamir transformed Chaum-Pedersen proof
+// that the same secret exponent was used to compute both a
+// KeyShare.Commitment (base Y) and a PartialDecryption.Value (base
+// cipherText), without revealing the exponent.
+type EqualityProof struct {
+	A1, A2   *big.Int
+	Response *big.Int
+}
+
+// coefficientHidingBits is the number of extra bits of range given to
+// the random (non-constant) coefficients of the secret-sharing
+// polynomial, beyond N's own bit length. It follows the Shoup/
+// Damgård-Koprowski technique for sharing a secret over the integers
+// rather than modulo a ring: each share statistically hides PhiDivR
+// as long as the coefficients are drawn from a range large enough
+// that PhiDivR's contribution to it is negligible.
+const coefficientHidingBits = 128
+
+// GenerateThresholdKey generates a Benaloh key of the given bit size
+// and splits its secret exponent into n Shamir shares, t of which
+// are required to decrypt a cipher text encrypted under the
+// returned public key.
+func GenerateThresholdKey(random io.Reader, bitsize, t, n int) (*PublicKey, []KeyShare, error) {
+	if t < 1 || t > n {
+		return nil, nil, ErrThreshold
+	}
+
+	priv, err := benaloh.GenerateKey(random, bitsize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// f(x) = PhiDivR + a1*x + ... + a(t-1)*x^(t-1), shared over the
+	// integers: PhiDivR must be reconstructed exactly by Lagrange
+	// interpolation, which only holds if neither the coefficients
+	// nor the shares are ever reduced modulo N or anything else.
+	bound := new(big.Int).Lsh(one, uint(priv.N.BitLen()+coefficientHidingBits))
+	coeffs := make([]*big.Int, t)
+	coeffs[0] = priv.PhiDivR
+	for i := 1; i < t; i++ {
+		c, err := rand.Int(random, bound)
+		if err != nil {
+			return nil, nil, err
+		}
+		coeffs[i] = c
+	}
+
+	shares := make([]KeyShare, n)
+	commitments := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		x := big.NewInt(int64(i + 1))
+		value := evalPoly(coeffs, x)
+		commitment := new(big.Int).Exp(priv.Y, value, priv.N)
+		shares[i] = KeyShare{
+			Index:      i + 1,
+			Value:      value,
+			Commitment: commitment,
+		}
+		commitments[i] = commitment
+	}
+
+	pub := &PublicKey{
+		PublicKey:   priv.PublicKey,
+		X:           priv.X,
+		Threshold:   t,
+		Trustees:    n,
+		Commitments: commitments,
+	}
+	return pub, shares, nil
+}
+
+// evalPoly evaluates the polynomial with the given coefficients
+// (lowest degree first) at x, over the integers. The result is never
+// reduced modulo anything: it is the exact value f(x), which is what
+// lets CombineShares reconstruct PhiDivR by Lagrange interpolation
+// rather than a value merely congruent to it.
+func evalPoly(coeffs []*big.Int, x *big.Int) *big.Int {
+	result := new(big.Int)
+	power := new(big.Int).Set(one)
+	for _, c := range coeffs {
+		result.Add(result, new(big.Int).Mul(c, power))
+		power.Mul(power, x)
+	}
+	return result
+}
+
+// PartialDecrypt computes a trustee's contribution towards
+// decrypting cipherText using share, along with a proof that it was
+// derived from the same exponent as share.Commitment.
+func PartialDecrypt(random io.Reader, pub *PublicKey, share KeyShare, cipherText []byte) (*PartialDecryption, error) {
+	c := new(big.Int).SetBytes(cipherText)
+	if c.Cmp(pub.N) == 1 {
+		return nil, benaloh.ErrLargeCipher
+	}
+
+	value := new(big.Int).Exp(c, share.Value, pub.N)
+
+	proof, err := proveEquality(random, pub.N, pub.Y, c, share.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PartialDecryption{
+		Index:      share.Index,
+		Value:      value,
+		Commitment: share.Commitment,
+		Proof:      proof,
+	}, nil
+}
+
+// CombineShares verifies at least pub.Threshold partial decryptions
+// of cipherText, combines them via Lagrange interpolation in the
+// exponent, and recovers the original message.
+func CombineShares(pub *PublicKey, cipherText []byte, partials ...*PartialDecryption) ([]byte, error) {
+	if len(partials) < pub.Threshold {
+		return nil, ErrNotEnoughShares
+	}
+	partials = partials[:pub.Threshold]
+
+	c := new(big.Int).SetBytes(cipherText)
+	if c.Cmp(pub.N) == 1 {
+		return nil, benaloh.ErrLargeCipher
+	}
+
+	for _, p := range partials {
+		if p.Index < 1 || p.Index > pub.Trustees {
+			return nil, ErrInvalidShare
+		}
+
+		// The commitment a partial is checked against must be the
+		// one GenerateThresholdKey actually published for this
+		// trustee index, not whatever Commitment rides along with
+		// the (potentially attacker-supplied) PartialDecryption;
+		// otherwise a forged partial with a self-consistent
+		// Commitment and Value would sail through verifyEquality
+		// without ever touching a real KeyShare.
+		canonical := pub.Commitments[p.Index-1]
+		if p.Commitment.Cmp(canonical) != 0 {
+			return nil, ErrInvalidShare
+		}
+
+		if !verifyEquality(pub.N, pub.Y, c, canonical, p.Value, p.Proof) {
+			return nil, ErrInvalidShare
+		}
+	}
+
+	indices := make([]*big.Int, len(partials))
+	for i, p := range partials {
+		indices[i] = big.NewInt(int64(p.Index))
+	}
+
+	// Lagrange coefficients at x = 0 are rationals, not integers, so
+	// they cannot be reduced modulo N and used directly as an
+	// exponent: c^PhiDivR mod N is not periodic with period N, only
+	// with period ord(c). Instead every coefficient is scaled by
+	// delta = Trustees!, which is guaranteed to clear every possible
+	// Lagrange denominator for distinct indices in [1, Trustees], so
+	// delta*lambda_i is always an exact integer.
+	delta := factorial(pub.Trustees)
+
+	// a = c^(delta*PhiDivR) mod n, reassembled as the product of each
+	// partial raised to its scaled Lagrange coefficient at x = 0,
+	// without any party ever learning PhiDivR itself.
+	a := new(big.Int).Set(one)
+	for i, p := range partials {
+		exp := scaledLagrangeCoefficient(indices, i, delta)
+
+		base := p.Value
+		if exp.Sign() < 0 {
+			base = new(big.Int).ModInverse(p.Value, pub.N)
+			exp = new(big.Int).Neg(exp)
+		}
+		a.Mod(a.Mul(a, new(big.Int).Exp(base, exp, pub.N)), pub.N)
+	}
+
+	// DiscreteLog recovers delta*m mod R, since X has order R; undo
+	// the delta scaling to recover m itself.
+	deltaM, err := benaloh.DiscreteLog(pub.X, pub.R, pub.N, a)
+	if err != nil {
+		return nil, err
+	}
+
+	deltaInv := new(big.Int).ModInverse(new(big.Int).Mod(delta, pub.R), pub.R)
+	if deltaInv == nil {
+		return nil, ErrTrusteesTooLarge
+	}
+	m := new(big.Int).Mod(
+		new(big.Int).Mul(new(big.Int).SetBytes(deltaM), deltaInv),
+		pub.R,
+	)
+	return m.Bytes(), nil
+}
+
+// factorial returns n! as a *big.Int.
+func factorial(n int) *big.Int {
+	result := new(big.Int).Set(one)
+	for i := int64(2); i <= int64(n); i++ {
+		result.Mul(result, big.NewInt(i))
+	}
+	return result
+}
+
+// scaledLagrangeCoefficient computes delta * lambda_i, where
+// lambda_i = prod_{j != i} (-x_j) / (x_i - x_j) is the Lagrange
+// basis polynomial for indices[i] evaluated at 0. Because delta is a
+// multiple of every possible Lagrange denominator for distinct
+// indices in [1, len(indices)]'s range, the division below is exact.
+func scaledLagrangeCoefficient(indices []*big.Int, i int, delta *big.Int) *big.Int {
+	num := new(big.Int).Set(one)
+	den := new(big.Int).Set(one)
+
+	xi := indices[i]
+	for j, xj := range indices {
+		if j == i {
+			continue
+		}
+		num.Mul(num, new(big.Int).Neg(xj))
+		den.Mul(den, new(big.Int).Sub(xi, xj))
+	}
+
+	scaled := new(big.Int).Mul(delta, num)
+	return scaled.Div(scaled, den)
+}
+
+// challengeBits bounds the Fiat-Shamir challenge produced by
+// challenge(): a SHA-256 digest read directly as an integer is always
+// below 2^challengeBits.
+const challengeBits = 256
+
+// proofHidingBits is the statistical security margin given to w's
+// sampling range in proveEquality, beyond what's needed to cover
+// e*value. It plays the same hiding role as coefficientHidingBits
+// does for the Shamir coefficients themselves.
+const proofHidingBits = 128
+
+// proveEquality produces a Chaum-Pedersen style proof that the same
+// exponent value was used to compute both commitment = y^value mod n
+// and partial = base^value mod n, without revealing value. Unlike a
+// textbook Schnorr proof over a group of known order, value here is
+// an unreduced integer Shamir share and can be far larger than n (see
+// coefficientHidingBits in GenerateThresholdKey), so w is drawn from
+// a range sized off value's own bit length rather than n: anything
+// smaller would leave e*value barely masked and leak value through
+// the response.
+func proveEquality(random io.Reader, n, y, base, value *big.Int) (*EqualityProof, error) {
+	bound := new(big.Int).Lsh(one, uint(value.BitLen()+challengeBits+proofHidingBits))
+	w, err := rand.Int(random, bound)
+	if err != nil {
+		return nil, err
+	}
+
+	a1 := new(big.Int).Exp(y, w, n)
+	a2 := new(big.Int).Exp(base, w, n)
+	e := challenge(n, y, base, a1, a2)
+
+	// z = w + e*value (kept as a plain integer; exponentiation mod
+	// n is correct for any exponent, so no further reduction of z
+	// is required).
+	z := new(big.Int).Add(w, new(big.Int).Mul(e, value))
+
+	return &EqualityProof{A1: a1, A2: a2, Response: z}, nil
+}
+
+// verifyEquality checks a proof produced by proveEquality against
+// commitment = y^value mod n and partial = base^value mod n.
+func verifyEquality(n, y, base, commitment, partial *big.Int, proof *EqualityProof) bool {
+	if proof == nil {
+		return false
+	}
+	e := challenge(n, y, base, proof.A1, proof.A2)
+
+	lhs1 := new(big.Int).Exp(y, proof.Response, n)
+	rhs1 := new(big.Int).Mod(
+		new(big.Int).Mul(proof.A1, new(big.Int).Exp(commitment, e, n)),
+		n,
+	)
+	if lhs1.Cmp(rhs1) != 0 {
+		return false
+	}
+
+	lhs2 := new(big.Int).Exp(base, proof.Response, n)
+	rhs2 := new(big.Int).Mod(
+		new(big.Int).Mul(proof.A2, new(big.Int).Exp(partial, e, n)),
+		n,
+	)
+	return lhs2.Cmp(rhs2) == 0
+}
+
+// challenge derives the Fiat-Shamir challenge for an equality proof
+// from its public inputs.
+func challenge(n, y, base, a1, a2 *big.Int) *big.Int {
+	h := sha256.New()
+	for _, v := range []*big.Int{n, y, base, a1, a2} {
+		h.Write(v.Bytes())
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
@@ -0,0 +1,118 @@
+package threshold
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// TestGenerateCombineRoundTrip exercises the full (t, n)-threshold
+// flow: generate a key, encrypt under it, partially decrypt with
+// exactly Threshold trustees, and combine the partials back into the
+// original plaintext.
+func TestGenerateCombineRoundTrip(t *testing.T) {
+	const trustees, threshold, bitsize = 5, 3, 24
+
+	pub, shares, err := GenerateThresholdKey(rand.Reader, bitsize, threshold, trustees)
+	if err != nil {
+		t.Fatalf("GenerateThresholdKey: %v", err)
+	}
+
+	plainText := []byte{42}
+	cipherText, err := pub.Encrypt(plainText)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	partials := make([]*PartialDecryption, 0, threshold)
+	for _, share := range shares[:threshold] {
+		p, err := PartialDecrypt(rand.Reader, pub, share, cipherText)
+		if err != nil {
+			t.Fatalf("PartialDecrypt(trustee %d): %v", share.Index, err)
+		}
+		partials = append(partials, p)
+	}
+
+	got, err := CombineShares(pub, cipherText, partials...)
+	if err != nil {
+		t.Fatalf("CombineShares: %v", err)
+	}
+	if len(got) != 1 || got[0] != plainText[0] {
+		t.Errorf("CombineShares = %v, want %v", got, plainText)
+	}
+}
+
+// TestCombineSharesNotEnough checks that fewer than Threshold
+// partials are rejected before any combination is attempted.
+func TestCombineSharesNotEnough(t *testing.T) {
+	pub, shares, err := GenerateThresholdKey(rand.Reader, 24, 3, 5)
+	if err != nil {
+		t.Fatalf("GenerateThresholdKey: %v", err)
+	}
+
+	cipherText, err := pub.Encrypt([]byte{1})
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	p, err := PartialDecrypt(rand.Reader, pub, shares[0], cipherText)
+	if err != nil {
+		t.Fatalf("PartialDecrypt: %v", err)
+	}
+
+	if _, err := CombineShares(pub, cipherText, p); err != ErrNotEnoughShares {
+		t.Errorf("CombineShares with 1 of 3 partials: got err %v, want %v", err, ErrNotEnoughShares)
+	}
+}
+
+// TestCombineSharesRejectsTamperedPartial checks that tampering with
+// either field of an otherwise legitimate PartialDecryption is caught
+// by CombineShares: a flipped Value breaks the equality proof, and a
+// flipped Commitment no longer matches the canonical commitment
+// published for that trustee.
+func TestCombineSharesRejectsTamperedPartial(t *testing.T) {
+	const trustees, threshold, bitsize = 5, 3, 24
+
+	pub, shares, err := GenerateThresholdKey(rand.Reader, bitsize, threshold, trustees)
+	if err != nil {
+		t.Fatalf("GenerateThresholdKey: %v", err)
+	}
+
+	cipherText, err := pub.Encrypt([]byte{42})
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	legitPartials := make([]*PartialDecryption, 0, threshold)
+	for _, share := range shares[:threshold] {
+		p, err := PartialDecrypt(rand.Reader, pub, share, cipherText)
+		if err != nil {
+			t.Fatalf("PartialDecrypt(trustee %d): %v", share.Index, err)
+		}
+		legitPartials = append(legitPartials, p)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(p *PartialDecryption)
+	}{
+		{"tampered Value", func(p *PartialDecryption) {
+			p.Value = new(big.Int).Add(p.Value, big.NewInt(1))
+		}},
+		{"tampered Commitment", func(p *PartialDecryption) {
+			p.Commitment = new(big.Int).Add(p.Commitment, big.NewInt(1))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := *legitPartials[0]
+			tt.mutate(&tampered)
+
+			partials := append([]*PartialDecryption{&tampered}, legitPartials[1:]...)
+			if _, err := CombineShares(pub, cipherText, partials...); err != ErrInvalidShare {
+				t.Errorf("CombineShares with %s: got err %v, want %v", tt.name, err, ErrInvalidShare)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package zkp
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/mirzazhar/benaloh"
+)
+
+// TestProveVerifyMembershipRoundTrip exercises the disjunctive
+// membership proof: a valid proof for the actual plaintext verifies,
+// and a proof claiming membership for a plaintext outside the
+// allowed set is rejected outright.
+func TestProveVerifyMembershipRoundTrip(t *testing.T) {
+	priv, err := benaloh.GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	plaintext := big.NewInt(1)
+	randomness, err := rand.Int(rand.Reader, new(big.Int).Sub(priv.N, big.NewInt(1)))
+	if err != nil {
+		t.Fatalf("rand.Int: %v", err)
+	}
+
+	cipherText := new(big.Int).Mod(
+		new(big.Int).Mul(
+			new(big.Int).Exp(priv.Y, plaintext, priv.N),
+			new(big.Int).Exp(randomness, priv.R, priv.N),
+		),
+		priv.N,
+	)
+
+	allowedSet := []*big.Int{big.NewInt(0), big.NewInt(1)}
+	proof, err := ProveMembership(&priv.PublicKey, plaintext, randomness, allowedSet)
+	if err != nil {
+		t.Fatalf("ProveMembership: %v", err)
+	}
+
+	if err := VerifyMembership(&priv.PublicKey, cipherText.Bytes(), allowedSet, proof); err != nil {
+		t.Errorf("VerifyMembership: %v", err)
+	}
+
+	if _, err := ProveMembership(&priv.PublicKey, big.NewInt(2), randomness, allowedSet); err != ErrNotInSet {
+		t.Errorf("ProveMembership with plaintext outside allowedSet: got err %v, want %v", err, ErrNotInSet)
+	}
+}
+
+// TestProveVerifyKnowledgeRoundTrip exercises the knowledge-of-
+// plaintext proof against a cipher text the prover actually produced.
+func TestProveVerifyKnowledgeRoundTrip(t *testing.T) {
+	priv, err := benaloh.GenerateKey(rand.Reader, 24)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	plaintext := big.NewInt(3)
+	randomness, err := rand.Int(rand.Reader, new(big.Int).Sub(priv.N, big.NewInt(1)))
+	if err != nil {
+		t.Fatalf("rand.Int: %v", err)
+	}
+
+	cipherText := new(big.Int).Mod(
+		new(big.Int).Mul(
+			new(big.Int).Exp(priv.Y, plaintext, priv.N),
+			new(big.Int).Exp(randomness, priv.R, priv.N),
+		),
+		priv.N,
+	)
+
+	proof, err := ProveKnowledgeOfPlaintext(rand.Reader, &priv.PublicKey, plaintext, randomness)
+	if err != nil {
+		t.Fatalf("ProveKnowledgeOfPlaintext: %v", err)
+	}
+
+	if err := VerifyKnowledgeOfPlaintext(&priv.PublicKey, cipherText.Bytes(), proof); err != nil {
+		t.Errorf("VerifyKnowledgeOfPlaintext: %v", err)
+	}
+}